@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+// dialTimeout bounds how long Client waits to connect to the daemon's
+// socket before giving up and letting the caller fall back to in-process
+// behavior.
+const dialTimeout = 2 * time.Second
+
+// Client implements credentials.Helper by proxying every call to a
+// Server over a Unix domain socket. It is the "thin client" that the
+// helper binary becomes when a daemon is running.
+type Client struct {
+	socketPath string
+}
+
+// NewClient returns a Client that connects to the Unix domain socket at
+// socketPath.
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// Available reports whether the daemon's socket can currently be
+// reached. Callers should fall back to an in-process Helper if this
+// returns false.
+func (c *Client) Available() bool {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (c *Client) call(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to daemon socket %s: %v", c.socketPath, err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("error sending request to daemon: %v", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("error reading response from daemon: %v", err)
+	}
+	if resp.Error != "" {
+		if resp.NotFound {
+			return nil, credentials.NewErrCredentialsNotFound()
+		}
+		return nil, fmt.Errorf(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Add implements credentials.Helper.
+func (c *Client) Add(creds *credentials.Credentials) error {
+	_, err := c.call(&Request{Verb: VerbStore, Credentials: creds})
+	return err
+}
+
+// Delete implements credentials.Helper.
+func (c *Client) Delete(serverURL string) error {
+	_, err := c.call(&Request{Verb: VerbErase, ServerURL: serverURL})
+	return err
+}
+
+// Get implements credentials.Helper.
+func (c *Client) Get(serverURL string) (string, string, error) {
+	resp, err := c.call(&Request{Verb: VerbGet, ServerURL: serverURL})
+	if err != nil {
+		return "", "", err
+	}
+	return resp.Username, resp.Password, nil
+}
+
+// List implements credentials.Helper.
+func (c *Client) List() (map[string]string, error) {
+	resp, err := c.call(&Request{Verb: VerbList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
@@ -0,0 +1,54 @@
+// Package daemon implements a long-running alternative to the
+// per-invocation docker-credential-vault-login binary: a server that
+// holds one authenticated Vault client and TokenLifecycleManager for the
+// life of the process, and a client that the short-lived binary invoked
+// by Docker can use to proxy its get/store/erase/list calls to that
+// server over a Unix domain socket instead of paying the cost of a full
+// config load and auth handshake on every invocation.
+package daemon
+
+import (
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+// DefaultSocketPath is used when neither the -socket flag nor the
+// EnvSocketPath environment variable specify one.
+const DefaultSocketPath = "/run/docker-credential-vault-login.sock"
+
+// EnvSocketPath overrides DefaultSocketPath.
+const EnvSocketPath = "DOCKER_CREDS_SOCKET_PATH"
+
+// Verb identifies which credentials.Helper method a Request invokes.
+type Verb string
+
+const (
+	VerbGet   Verb = "get"
+	VerbStore Verb = "store"
+	VerbErase Verb = "erase"
+	VerbList  Verb = "list"
+)
+
+// Request is one line-delimited JSON frame sent from Client to Server.
+type Request struct {
+	Verb        Verb                     `json:"verb"`
+	ServerURL   string                   `json:"server_url,omitempty"`
+	Credentials *credentials.Credentials `json:"credentials,omitempty"`
+}
+
+// Response is one line-delimited JSON frame sent from Server to Client.
+// Error is set instead of the other fields when the underlying Helper
+// call failed.
+type Response struct {
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	List     map[string]string `json:"list,omitempty"`
+	Error    string            `json:"error,omitempty"`
+
+	// NotFound is set alongside Error when the failure was
+	// credentials.NewErrCredentialsNotFound(), so the Client can
+	// reconstruct that sentinel instead of a generic error. Docker's
+	// credential-helper protocol matches on this sentinel's message to
+	// decide a registry simply has no stored credentials, as opposed to
+	// a real failure.
+	NotFound bool `json:"not_found,omitempty"`
+}
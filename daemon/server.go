@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/hashicorp/go-hclog"
+)
+
+// Helper is the subset of docker-credential-helpers' credentials.Helper
+// interface that the daemon proxies over the socket. Any *helper.Helper
+// satisfies it.
+type Helper interface {
+	Add(creds *credentials.Credentials) error
+	Delete(serverURL string) error
+	Get(serverURL string) (string, string, error)
+	List() (map[string]string, error)
+}
+
+// Server accepts connections on a Unix domain socket and dispatches each
+// request to the Helper it wraps. It is intended to be constructed once
+// and run for the life of a long-running daemon process, so that the
+// Helper's cached Vault client and token lifecycle manager are reused
+// across every `docker pull`/`docker push` instead of rebuilt per call.
+type Server struct {
+	logger hclog.Logger
+	helper Helper
+}
+
+// NewServer constructs a Server that dispatches requests to helper.
+func NewServer(logger hclog.Logger, helper Helper) *Server {
+	return &Server{
+		logger: logger,
+		helper: helper,
+	}
+}
+
+// ListenAndServe listens on the Unix domain socket at socketPath (mode
+// 0600) and serves requests until the listener is closed or Accept
+// returns an error.
+func (s *Server) ListenAndServe(socketPath string) error {
+	if err := os.RemoveAll(socketPath); err != nil {
+		return fmt.Errorf("error removing stale socket %s: %v", socketPath, err)
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %v", socketPath, err)
+	}
+	defer l.Close()
+
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		return fmt.Errorf("error setting permissions on %s: %v", socketPath, err)
+	}
+
+	s.logger.Info("Daemon listening", "socket", socketPath)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("error accepting connection: %v", err)
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		s.logger.Error("Error decoding request", "error", err)
+		return
+	}
+
+	resp := s.dispatch(&req)
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+func (s *Server) dispatch(req *Request) *Response {
+	switch req.Verb {
+	case VerbGet:
+		username, password, err := s.helper.Get(req.ServerURL)
+		if err != nil {
+			notFound := err.Error() == credentials.NewErrCredentialsNotFound().Error()
+			return &Response{Error: err.Error(), NotFound: notFound}
+		}
+		return &Response{Username: username, Password: password}
+
+	case VerbStore:
+		if err := s.helper.Add(req.Credentials); err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{}
+
+	case VerbErase:
+		if err := s.helper.Delete(req.ServerURL); err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{}
+
+	case VerbList:
+		list, err := s.helper.List()
+		if err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{List: list}
+
+	default:
+		return &Response{Error: fmt.Sprintf("unknown verb %q", req.Verb)}
+	}
+}
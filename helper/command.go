@@ -1,9 +1,11 @@
-package helper 
+package helper
 
 import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/api"
@@ -21,8 +23,12 @@ import (
 	"github.com/hashicorp/vault/command/agent/sink"
 	"github.com/hashicorp/vault/command/agent/sink/file"
 	"github.com/morningconsult/docker-credential-vault-login/cache"
+	"github.com/morningconsult/docker-credential-vault-login/lifecycle"
+	"github.com/morningconsult/docker-credential-vault-login/registry"
 	"github.com/morningconsult/docker-credential-vault-login/vault"
 	"github.com/morningconsult/docker-credential-vault-login/logging"
+	keyringsink "github.com/morningconsult/docker-credential-vault-login/sink/keyring"
+	memorysink "github.com/morningconsult/docker-credential-vault-login/sink/memory"
 )
 
 const (
@@ -30,10 +36,7 @@ const (
 	defaultConfigFile = "/etc/docker-credential-vault-login/config.hcl"
 )
 
-var (
-	notImplementedError = fmt.Errorf("not implemented")
-	defaultTimeout = 10 * time.Second
-)
+var defaultTimeout = 10 * time.Second
 
 type HelperOptions struct {
 	Logger hclog.Logger
@@ -41,8 +44,26 @@ type HelperOptions struct {
 }
 
 type Helper struct {
-	logger hclog.Logger
-	client *api.Client
+	logger         hclog.Logger
+	client         *api.Client
+	lifecycleMgr   *lifecycle.Manager
+	registryTokens *registry.Cache
+
+	// memorySink and keyringSink are kept on the Helper, rather than
+	// rebuilt on every call to buildSinks, so that a token written to
+	// them during one call can actually be read back on the next
+	// (within the lifetime of this process, notably in daemon mode).
+	memorySink  *memorysink.Sink
+	keyringSink *keyringsink.Sink
+
+	// mu serializes authenticate(), which reads and mutates client,
+	// lifecycleMgr, memorySink and keyringSink. In daemon mode a single
+	// Helper is shared across every connection, each served on its own
+	// goroutine (see daemon.Server.handleConn), so without this lock two
+	// concurrent requests can race: both observe lifecycleMgr == nil and
+	// start duplicate managers, or one clears the client's token while
+	// another is mid-request with a token just set.
+	mu sync.Mutex
 }
 
 func NewHelper(opts *HelperOptions) *Helper {
@@ -57,122 +78,372 @@ func NewHelper(opts *HelperOptions) *Helper {
 }
 
 func (h *Helper) Add(creds *credentials.Credentials) error {
-	return notImplementedError
+	cfg, err := h.loadConfig()
+	if err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if !h.writable(cfg) {
+		return fmt.Errorf("'writable' is not set to true in auto_auth.method.config; refusing to write credentials")
+	}
+
+	secret, err := h.secretPath(cfg, creds.ServerURL)
+	if err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if err := h.authenticate(cfg); err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if err := vault.WriteCredentials(secret, &vault.Credentials{
+		Username: creds.Username,
+		Password: creds.Password,
+	}, h.client); err != nil {
+		h.logger.Error("Error writing credentials to Vault", "error", err)
+		return err
+	}
+	return nil
 }
 
 func (h *Helper) Delete(serverURL string) error {
-	return notImplementedError
+	cfg, err := h.loadConfig()
+	if err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if !h.writable(cfg) {
+		return fmt.Errorf("'writable' is not set to true in auto_auth.method.config; refusing to delete credentials")
+	}
+
+	secret, err := h.secretPath(cfg, serverURL)
+	if err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if err := h.authenticate(cfg); err != nil {
+		h.logger.Error(err.Error())
+		return err
+	}
+
+	if err := vault.DeleteCredentials(secret, h.client); err != nil {
+		h.logger.Error("Error deleting credentials from Vault", "error", err)
+		return err
+	}
+	return nil
 }
 
 func (h *Helper) List() (map[string]string, error) {
-	return nil, notImplementedError
+	cfg, err := h.loadConfig()
+	if err != nil {
+		h.logger.Error(err.Error())
+		return nil, err
+	}
+
+	prefixes, err := h.listPrefixes(cfg)
+	if err != nil {
+		h.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if err := h.authenticate(cfg); err != nil {
+		h.logger.Error(err.Error())
+		return nil, err
+	}
+
+	out := make(map[string]string)
+	for _, prefix := range prefixes {
+		list, err := vault.ListCredentials(prefix, h.client)
+		if err != nil {
+			h.logger.Error("Error listing credentials from Vault", "prefix", prefix, "error", err)
+			continue
+		}
+		for serverURL, username := range list {
+			out[serverURL] = username
+		}
+	}
+	return out, nil
 }
 
 func (h *Helper) Get(serverURL string) (string, string, error) {
-	// Create new logger
-	if h.logger == nil {
-		opts := &hclog.LoggerOptions{
-			Name:   "helper.get",
-			Level:  hclog.Error,
-			Output: os.Stderr,
+	cfg, err := h.loadConfig()
+	if err != nil {
+		h.logger.Error(err.Error())
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+
+	secret, err := h.secretPath(cfg, serverURL)
+	if err != nil {
+		h.logger.Error(err.Error())
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+
+	if err := h.authenticate(cfg); err != nil {
+		h.logger.Error(err.Error())
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+
+	switch h.credentialType(cfg) {
+	case credentialTypeIdentityToken:
+		token, err := vault.GetToken(secret, h.client)
+		if err != nil {
+			h.logger.Error("Error reading identity token from Vault", "error", err)
+			return "", "", credentials.NewErrCredentialsNotFound()
+		}
+		return identityTokenUsername, token, nil
+
+	case credentialTypeRegistryBearer:
+		creds, err := vault.GetCredentials(secret, h.client)
+		if err != nil {
+			h.logger.Error("Error reading secret from Vault", "error", err)
+			return "", "", credentials.NewErrCredentialsNotFound()
 		}
 
-		w, err := logging.LogWriter(nil)
+		scope, _ := cfg.AutoAuth.Method.Config["registry_scope"].(string)
+
+		if h.registryTokens == nil {
+			h.registryTokens = registry.NewCache(nil)
+		}
+
+		bearer, err := h.registryTokens.Token(serverURL, scope, creds.Username, creds.Password)
 		if err != nil {
-			h.logger.Error("Error opening log file. Logging errors to stderr instead.", "error", err)
-		} else {
-			opts.Output = w
-			defer w.Close()
+			h.logger.Error("Error exchanging Vault credentials for a registry bearer token", "error", err)
+			return "", "", credentials.NewErrCredentialsNotFound()
 		}
+		return identityTokenUsername, bearer, nil
+
+	default:
+		creds, err := vault.GetCredentials(secret, h.client)
+		if err != nil {
+			h.logger.Error("Error reading secret from Vault", "error", err)
+			return "", "", credentials.NewErrCredentialsNotFound()
+		}
+		return creds.Username, creds.Password, nil
+	}
+}
+
+// credentialTypeUserpass, credentialTypeIdentityToken and
+// credentialTypeRegistryBearer are the supported values of
+// auto_auth.method.config.credential_type.
+const (
+	credentialTypeUserpass      = "userpass"
+	credentialTypeIdentityToken = "identity_token"
+	credentialTypeRegistryBearer = "registry_bearer"
+)
+
+// identityTokenUsername is the sentinel username Docker expects when the
+// password field is actually an OAuth2-style identity token rather than
+// a literal password. See the IdentityToken field in Docker's
+// credential-helper protocol.
+const identityTokenUsername = "<token>"
+
+// credentialType returns the configured auto_auth.method.config.credential_type,
+// defaulting to credentialTypeUserpass.
+func (h *Helper) credentialType(cfg *config.Config) string {
+	raw, ok := cfg.AutoAuth.Method.Config["credential_type"]
+	if !ok {
+		return credentialTypeUserpass
+	}
+
+	credType, ok := raw.(string)
+	if !ok {
+		return credentialTypeUserpass
+	}
+	return credType
+}
+
+// ensureLogger lazily creates h.logger the first time the Helper is used,
+// mirroring the fact that a Helper built by docker-credential-helpers'
+// credentials.Serve is constructed with NewHelper(nil).
+func (h *Helper) ensureLogger() {
+	if h.logger != nil {
+		return
+	}
+
+	opts := &hclog.LoggerOptions{
+		Name:   "helper",
+		Level:  hclog.Error,
+		Output: os.Stderr,
+	}
 
-		h.logger = hclog.New(opts)
+	w, err := logging.LogWriter(nil)
+	if err != nil {
+		opts.Output = os.Stderr
+	} else {
+		opts.Output = w
 	}
 
+	h.logger = hclog.New(opts)
+}
+
+// loadConfig reads and validates the auto_auth configuration file,
+// creating h.logger if it does not already exist.
+func (h *Helper) loadConfig() (*config.Config, error) {
+	h.ensureLogger()
+
 	configFile := defaultConfigFile
 	if f := os.Getenv(envConfigFile); f != "" {
 		configFile = f
 	}
 
-	config, err := config.LoadConfig(configFile, h.logger)
+	cfg, err := config.LoadConfig(configFile, h.logger)
 	if err != nil {
-		h.logger.Error(fmt.Sprintf("Error loading configuration from %s", configFile), "error", err)
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return nil, fmt.Errorf("Error loading configuration from %s: %v", configFile, err)
 	}
 
-	if config == nil {
-		h.logger.Error("No configuration read. Please provide the configuration file with the " +
+	if cfg == nil {
+		return nil, fmt.Errorf("No configuration read. Please provide the configuration file with the " +
 			envConfigFile + " environment variable.")
-		return "", "", credentials.NewErrCredentialsNotFound()
 	}
 
-	if config.AutoAuth == nil {
-		h.logger.Error(fmt.Sprintf("No auto_auth block found in configuration file %s", configFile))
-		return "", "", credentials.NewErrCredentialsNotFound()
+	if cfg.AutoAuth == nil {
+		return nil, fmt.Errorf("No auto_auth block found in configuration file %s", configFile)
+	}
+
+	return cfg, nil
+}
+
+// listPrefixes returns the set of Vault paths that List should walk. The
+// legacy single "secret" field is used as-is; entries in the "secrets"
+// map are only usable here if they contain no template directives,
+// since List has no serverURL to render {{.Host}}/{{.Namespace}} with.
+func (h *Helper) listPrefixes(cfg *config.Config) ([]string, error) {
+	if secretsRaw, ok := cfg.AutoAuth.Method.Config["secrets"]; ok {
+		patterns, ok := secretsRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field auto_auth.method.config.secrets could not be converted to a map")
+		}
+
+		var prefixes []string
+		for pattern, tmplRaw := range patterns {
+			tmplStr, ok := tmplRaw.(string)
+			if !ok {
+				return nil, fmt.Errorf("value for pattern %q in auto_auth.method.config.secrets could not be "+
+					"converted to string", pattern)
+			}
+			if strings.Contains(tmplStr, "{{") {
+				h.logger.Warn("Skipping templated secret path for List; it has no registry to render against",
+					"pattern", pattern)
+				continue
+			}
+			prefixes = append(prefixes, tmplStr)
+		}
+		return prefixes, nil
 	}
 
-	secretRaw, ok := config.AutoAuth.Method.Config["secret"]
+	secretRaw, ok := cfg.AutoAuth.Method.Config["secret"]
 	if !ok {
-		h.logger.Error(fmt.Sprintf("No 'secret' field found in auto_auth.method.config of " +
-			"configuration file %s", configFile))
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return nil, fmt.Errorf("No 'secret' or 'secrets' field found in auto_auth.method.config")
 	}
 
 	secret, ok := secretRaw.(string)
 	if !ok {
-		h.logger.Error(fmt.Sprintf("field auto_auth.method.config.secret of configuration file %s " +
-			"could not be converted to string", configFile))
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return nil, fmt.Errorf("field auto_auth.method.config.secret could not be converted to string")
+	}
+	return []string{secret}, nil
+}
+
+// writable reports whether auto_auth.method.config.writable is set to
+// true. It defaults to false so that existing, read-only deployments
+// keep their current behavior.
+func (h *Helper) writable(cfg *config.Config) bool {
+	writableRaw, ok := cfg.AutoAuth.Method.Config["writable"]
+	if !ok {
+		return false
+	}
+
+	writable, ok := writableRaw.(bool)
+	if !ok {
+		return false
 	}
+	return writable
+}
+
+// authenticate ensures that h.client holds a live Vault token, reusing a
+// cached token where possible and falling back to a full auth handshake
+// otherwise. A background TokenLifecycleManager is (re)used to keep
+// whichever token is selected alive so that subsequent calls can skip
+// the handshake entirely.
+func (h *Helper) authenticate(cfg *config.Config) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
+	var err error
 	if h.client == nil {
 		h.client, err = api.NewClient(nil)
 		if err != nil {
-			h.logger.Error("Error creating new Vault API client", "error", err)
-			return "", "", credentials.NewErrCredentialsNotFound()
+			return fmt.Errorf("Error creating new Vault API client: %v", err)
 		}
 	}
 
 	cloned, _ := h.client.Clone()
 
 	// Get any cached tokens
-	cachedTokens, err := cache.GetCachedTokens(config.AutoAuth.Sinks, cloned)
+	cachedTokens, err := cache.GetCachedTokens(cfg.AutoAuth.Sinks, cloned)
 	if err != nil {
 		h.logger.Error("Error getting cached token(s). Re-authenticating.", "error", err)
 	}
 
-	// Renew the cached tokens
-	for _, token := range cachedTokens {
-		if _, err := h.client.Auth().Token().RenewTokenAsSelf(token, 0); err != nil {
-			h.logger.Error("Error renewing token", "error", err)
+	// cache.GetCachedTokens only knows how to read the built-in file
+	// sink; memory and keyring sinks are read back directly from the
+	// Sink instances this Helper holds. Those instances are normally
+	// only built by buildSinks, further down, after deciding to
+	// reauthenticate -- but a keyring sink persists outside this
+	// process, so a CLI invocation (which always starts with a fresh,
+	// empty Helper) needs to probe it here too, before giving up on
+	// every cached token and paying for a full auth handshake.
+	h.ensureCachedSinks(cfg.AutoAuth.Sinks)
+	for _, sc := range cfg.AutoAuth.Sinks {
+		if token, ok := h.cachedSinkToken(sc); ok {
+			cachedTokens = append(cachedTokens, token)
 		}
 	}
 
-	// Use any token to get credentials
+	// Use any cached token that the lifecycle manager confirms is still
+	// alive, rather than renewing synchronously on every invocation.
 	for _, token := range cachedTokens {
 		h.client.SetToken(token)
 
-		// Get credentials
-		creds, err := vault.GetCredentials(secret, h.client)
-		if err != nil {
-			h.logger.Error("Error reading secret from Vault", "error", err)
-			continue
+		if h.lifecycleMgr == nil {
+			mgr := lifecycle.NewManager(&lifecycle.ManagerConfig{
+				Logger: h.logger.Named("lifecycle"),
+				Client: h.client,
+			})
+			// The renewal loop this starts must outlive authenticate
+			// itself, so it is given context.Background() rather than
+			// a context scoped (and canceled) here.
+			if startErr := mgr.Start(context.Background(), token); startErr != nil {
+				h.logger.Error("Error starting token lifecycle manager", "error", startErr)
+				continue
+			}
+			h.lifecycleMgr = mgr
+		}
+
+		select {
+		case <-h.lifecycleMgr.Healthy():
+			return nil
+		case <-time.After(defaultTimeout):
+			h.logger.Error(fmt.Sprintf("Timed out waiting for token lifecycle manager to report healthy within %s", defaultTimeout.String()))
 		}
-		return creds.Username, creds.Password, nil
 	}
 
-	// Failed to read secret with cached token. Reauthenticate.
+	// No cached token is usable. Reauthenticate.
 	h.client.ClearToken()
+	h.lifecycleMgr = nil
 
-	sinks, err := h.buildSinks(config.AutoAuth.Sinks)
+	sinks, err := h.buildSinks(cfg.AutoAuth.Sinks)
 	if err != nil {
-		h.logger.Error("Error building sinks", "error", err)
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return fmt.Errorf("Error building sinks: %v", err)
 	}
 
-	method, err := h.buildMethod(config.AutoAuth.Method)
+	method, err := h.buildMethod(cfg.AutoAuth.Method)
 	if err != nil {
-		h.logger.Error("Error building method", "error", err)
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return fmt.Errorf("Error building method: %v", err)
 	}
 
 	ss := sink.NewSinkServer(&sink.SinkServerConfig{
@@ -181,11 +452,12 @@ func (h *Helper) Get(serverURL string) (string, string, error) {
 		ExitAfterAuth: true,
 	})
 
-	ah := auth.NewAuthHandler(&auth.AuthHandlerConfig{
+	authHandlerConfig := &auth.AuthHandlerConfig{
 		Logger:  h.logger.Named("auth.handler"),
 		Client:  h.client,
-		WrapTTL: config.AutoAuth.Method.WrapTTL,
-	})
+		WrapTTL: cfg.AutoAuth.Method.WrapTTL,
+	}
+	ah := auth.NewAuthHandler(authHandlerConfig)
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 
@@ -197,10 +469,9 @@ func (h *Helper) Get(serverURL string) (string, string, error) {
 	var token string
 	select {
 	case <-ctx.Done():
-		h.logger.Error(fmt.Sprintf("Failed to get credentials within timeout (%s)", defaultTimeout.String()))
 		<-ah.DoneCh
 		<-ss.DoneCh
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return fmt.Errorf("Failed to get credentials within timeout (%s)", defaultTimeout.String())
 	case token = <-ah.OutputCh:
 		// will have to unwrap if wrapped
 		h.logger.Info("Successfully authenticated")
@@ -210,10 +481,9 @@ func (h *Helper) Get(serverURL string) (string, string, error) {
 
 	select {
 	case <-ctx.Done():
-		h.logger.Error(fmt.Sprintf("Failed to write token to sink(s) within the timeout (%s)", defaultTimeout.String()))
 		<-ah.DoneCh
 		<-ss.DoneCh
-		return "", "", credentials.NewErrCredentialsNotFound()
+		return fmt.Errorf("Failed to write token to sink(s) within the timeout (%s)", defaultTimeout.String())
 	case <-ss.DoneCh:
 		h.logger.Info("Successfully wrote token to sink(s)")
 	}
@@ -223,42 +493,137 @@ func (h *Helper) Get(serverURL string) (string, string, error) {
 
 	h.client.SetToken(token)
 
-	// Get credentials
-	creds, err := vault.GetCredentials(secret, h.client)
-	if err != nil {
-		h.logger.Error("Error reading secret from Vault", "error", err)
-		return "", "", credentials.NewErrCredentialsNotFound()
+	mgr := lifecycle.NewManager(&lifecycle.ManagerConfig{
+		Logger:            h.logger.Named("lifecycle"),
+		Client:            h.client,
+		AuthMethod:        method,
+		AuthHandlerConfig: authHandlerConfig,
+		Sinks:             sinks,
+	})
+	if err := mgr.Start(context.Background(), token); err != nil {
+		h.logger.Error("Error starting token lifecycle manager", "error", err)
+	} else {
+		h.lifecycleMgr = mgr
+	}
+
+	return nil
+}
+
+// cachedSinkToken returns the most recently written token for a memory
+// or keyring sink that this Helper has already built, if sc describes
+// one of those types. It only handles sinks configured with neither
+// response wrapping nor DH encryption: unwrapping/decrypting those is
+// the same logic cache.GetCachedTokens already applies to the file
+// sink, which this Helper does not otherwise duplicate.
+func (h *Helper) cachedSinkToken(sc *config.Sink) (string, bool) {
+	if sc.WrapTTL != 0 || sc.DHType != "" {
+		return "", false
+	}
+
+	switch sc.Type {
+	case "memory":
+		if h.memorySink == nil {
+			return "", false
+		}
+		token, ok := h.memorySink.Token()
+		if !ok {
+			return "", false
+		}
+		return string(token), true
+	case "keyring":
+		if h.keyringSink == nil {
+			return "", false
+		}
+		token, err := h.keyringSink.Token()
+		if err != nil {
+			return "", false
+		}
+		return string(token), true
+	default:
+		return "", false
 	}
-	return creds.Username, creds.Password, nil
 }
 
 func (h *Helper) buildSinks(ss []*config.Sink) ([]*sink.SinkConfig, error) {
 	var sinks []*sink.SinkConfig
 	for _, sc := range ss {
+		config := h.newSinkConfig(sc)
 		switch sc.Type {
 		case "file":
-			config := &sink.SinkConfig{
-				Logger:  h.logger.Named("sink.file"),
-				Config:  sc.Config,
-				Client:  h.client,
-				WrapTTL: sc.WrapTTL,
-				DHType:  sc.DHType,
-				DHPath:  sc.DHPath,
-				AAD:     sc.AAD,
-			}
 			s, err := file.NewFileSink(config)
 			if err != nil {
 				return nil, fmt.Errorf("Error creating file sink: %v", err)
 			}
 			config.Sink = s
-			sinks = append(sinks, config)
+		case "memory":
+			// Reuse the same Sink across calls so a token written to
+			// it on one call can be read back on the next; a new Sink
+			// every time would have its sync.Map garbage collected
+			// before anything ever read from it.
+			if h.memorySink == nil {
+				s, err := memorysink.NewSink(config)
+				if err != nil {
+					return nil, fmt.Errorf("Error creating memory sink: %v", err)
+				}
+				h.memorySink = s.(*memorysink.Sink)
+			}
+			config.Sink = h.memorySink
+		case "keyring":
+			if h.keyringSink == nil {
+				s, err := keyringsink.NewSink(config)
+				if err != nil {
+					return nil, fmt.Errorf("Error creating keyring sink: %v", err)
+				}
+				h.keyringSink = s.(*keyringsink.Sink)
+			}
+			config.Sink = h.keyringSink
 		default:
 			return nil, fmt.Errorf("Unknown sink type %q", sc.Type)
 		}
+		sinks = append(sinks, config)
 	}
 	return sinks, nil
 }
 
+// newSinkConfig builds the sink.SinkConfig common to every sink type from
+// a single configured sink entry. Callers still set the Sink field.
+func (h *Helper) newSinkConfig(sc *config.Sink) *sink.SinkConfig {
+	return &sink.SinkConfig{
+		Logger:  h.logger.Named("sink." + sc.Type),
+		Config:  sc.Config,
+		Client:  h.client,
+		WrapTTL: sc.WrapTTL,
+		DHType:  sc.DHType,
+		DHPath:  sc.DHPath,
+		AAD:     sc.AAD,
+	}
+}
+
+// ensureCachedSinks lazily constructs h.memorySink/h.keyringSink for any
+// configured memory/keyring sinks, so cachedSinkToken can probe them for a
+// token even before buildSinks has ever run. This matters most for the
+// keyring sink: it persists outside this process, so a token it holds may
+// have been written by an earlier invocation of this same binary, not just
+// earlier in the lifetime of the current Helper.
+func (h *Helper) ensureCachedSinks(ss []*config.Sink) {
+	for _, sc := range ss {
+		switch sc.Type {
+		case "memory":
+			if h.memorySink == nil {
+				if s, err := memorysink.NewSink(h.newSinkConfig(sc)); err == nil {
+					h.memorySink = s.(*memorysink.Sink)
+				}
+			}
+		case "keyring":
+			if h.keyringSink == nil {
+				if s, err := keyringsink.NewSink(h.newSinkConfig(sc)); err == nil {
+					h.keyringSink = s.(*keyringsink.Sink)
+				}
+			}
+		}
+	}
+}
+
 func (h *Helper) buildMethod(config *config.Method) (auth.AuthMethod, error) {
 	var (
 		method auth.AuthMethod
@@ -293,4 +658,3 @@ func (h *Helper) buildMethod(config *config.Method) (auth.AuthMethod, error) {
 	}
 	return method, nil
 }
-
@@ -0,0 +1,147 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/vault/command/agent/config"
+)
+
+// secretTemplateData holds the variables available to secret path
+// templates: {{.ServerURL}}, {{.Host}} and {{.Namespace}}.
+type secretTemplateData struct {
+	// ServerURL is the registry identifier exactly as Docker passed it
+	// to the credential helper, e.g. "https://index.docker.io/v1/".
+	ServerURL string
+
+	// Host is ServerURL with any scheme and path stripped, e.g.
+	// "123456789012.dkr.ecr.us-east-1.amazonaws.com".
+	Host string
+
+	// Namespace is the first path segment following Host, if any, e.g.
+	// "myorg" for "ghcr.io/myorg/myimage".
+	Namespace string
+}
+
+func newSecretTemplateData(serverURL string) secretTemplateData {
+	host := serverURL
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+len("://"):]
+	}
+
+	namespace := ""
+	if i := strings.Index(host, "/"); i >= 0 {
+		rest := strings.Trim(host[i+1:], "/")
+		host = host[:i]
+		if rest != "" {
+			namespace = strings.SplitN(rest, "/", 2)[0]
+		}
+	}
+
+	return secretTemplateData{
+		ServerURL: serverURL,
+		Host:      host,
+		Namespace: namespace,
+	}
+}
+
+// secretPath resolves the Vault path that credentials for serverURL
+// should be read from or written to. It supports the legacy
+// auto_auth.method.config.secret string as well as the newer
+// auto_auth.method.config.secrets map, which routes by registry
+// hostname glob and renders the matched value as a text/template.
+func (h *Helper) secretPath(cfg *config.Config, serverURL string) (string, error) {
+	if secretsRaw, ok := cfg.AutoAuth.Method.Config["secrets"]; ok {
+		patterns, ok := secretsRaw.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("field auto_auth.method.config.secrets could not be converted to a map")
+		}
+
+		tmplStr, err := bestSecretTemplate(patterns, newSecretTemplateData(serverURL).Host)
+		if err != nil {
+			return "", err
+		}
+		return renderSecretTemplate(tmplStr, serverURL)
+	}
+
+	secretRaw, ok := cfg.AutoAuth.Method.Config["secret"]
+	if !ok {
+		return "", fmt.Errorf("No 'secret' or 'secrets' field found in auto_auth.method.config")
+	}
+
+	secret, ok := secretRaw.(string)
+	if !ok {
+		return "", fmt.Errorf("field auto_auth.method.config.secret could not be converted to string")
+	}
+	return renderSecretTemplate(secret, serverURL)
+}
+
+// bestSecretTemplate returns the template string whose glob pattern is
+// the most specific match for host. Specificity is the number of
+// characters in the pattern other than '*' (a '?' or a bracketed
+// character class counts like a literal), so "*.dkr.ecr.*.amazonaws.com"
+// loses to "123456789012.dkr.ecr.us-east-1.amazonaws.com" if both match.
+func bestSecretTemplate(patterns map[string]interface{}, host string) (string, error) {
+	// Iterate patterns in a fixed order so that, on a tie in
+	// globSpecificity, the result does not depend on Go's randomized
+	// map iteration order.
+	keys := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		keys = append(keys, pattern)
+	}
+	sort.Strings(keys)
+
+	var (
+		best      string
+		bestScore = -1
+	)
+
+	// keys is sorted, so when two patterns tie on specificity the first
+	// one encountered here (the lexicographically smaller pattern) is
+	// kept, making the outcome independent of map iteration order.
+	for _, pattern := range keys {
+		matched, err := path.Match(pattern, host)
+		if err != nil {
+			return "", fmt.Errorf("invalid glob pattern %q in auto_auth.method.config.secrets: %v", pattern, err)
+		}
+		if !matched {
+			continue
+		}
+
+		if score := globSpecificity(pattern); score > bestScore {
+			tmplStr, ok := patterns[pattern].(string)
+			if !ok {
+				return "", fmt.Errorf("value for pattern %q in auto_auth.method.config.secrets could not be "+
+					"converted to string", pattern)
+			}
+			best = tmplStr
+			bestScore = score
+		}
+	}
+
+	if bestScore < 0 {
+		return "", fmt.Errorf("no entry in auto_auth.method.config.secrets matches registry host %q", host)
+	}
+	return best, nil
+}
+
+func globSpecificity(pattern string) int {
+	return len(strings.ReplaceAll(pattern, "*", ""))
+}
+
+func renderSecretTemplate(tmplStr, serverURL string) (string, error) {
+	tmpl, err := template.New("secret").Parse(tmplStr)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing secret path template %q: %v", tmplStr, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newSecretTemplateData(serverURL)); err != nil {
+		return "", fmt.Errorf("Error rendering secret path template %q: %v", tmplStr, err)
+	}
+	return buf.String(), nil
+}
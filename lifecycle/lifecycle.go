@@ -0,0 +1,314 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/morningconsult/docker-credential-vault-login/vault-login/cache"
+)
+
+// Renewal result labels used by Counters. These mirror the "result" label
+// on the vault_token_renewals_total metric.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultExpired = "expired"
+)
+
+// jitterFraction is the fraction of the token's remaining TTL that is
+// subtracted at random when computing the renewal grace window, so that
+// many helper processes sharing the same token do not all renew at once.
+const jitterFraction = 0.10
+
+// ManagerConfig holds the dependencies required to construct a Manager.
+type ManagerConfig struct {
+	Logger hclog.Logger
+	Client *api.Client
+
+	// AuthMethod and AuthHandlerConfig are used to re-authenticate when
+	// the current token can no longer be renewed.
+	AuthMethod        auth.AuthMethod
+	AuthHandlerConfig *auth.AuthHandlerConfig
+
+	// Sinks is written the new token after a successful re-authentication,
+	// the same way the initial auth handshake in Helper.authenticate
+	// writes it, so that a stale token does not keep being read back out
+	// of the cache on the next invocation.
+	Sinks []*sink.SinkConfig
+}
+
+// Manager keeps a Vault token alive in the background using Vault's
+// Renewer API, re-authenticating via the configured auth method when the
+// token is no longer renewable.
+type Manager struct {
+	logger hclog.Logger
+	client *api.Client
+
+	authMethod        auth.AuthMethod
+	authHandlerConfig *auth.AuthHandlerConfig
+	sinks             []*sink.SinkConfig
+
+	counters *Counters
+
+	healthyCh chan struct{}
+	doneCh    chan struct{}
+}
+
+// Counters are Prometheus-style counters tracking renewal outcomes.
+// Snapshot returns a point-in-time copy keyed by the metric name and
+// result label, e.g. "vault_token_renewals_total{result=success}".
+type Counters struct {
+	success int64
+	errors  int64
+	expired int64
+}
+
+func (c *Counters) record(result string) {
+	switch result {
+	case ResultSuccess:
+		atomic.AddInt64(&c.success, 1)
+	case ResultError:
+		atomic.AddInt64(&c.errors, 1)
+	case ResultExpired:
+		atomic.AddInt64(&c.expired, 1)
+	}
+}
+
+// Snapshot returns the current value of every vault_token_renewals_total
+// series.
+func (c *Counters) Snapshot() map[string]int64 {
+	return map[string]int64{
+		"vault_token_renewals_total{result=" + ResultSuccess + "}": atomic.LoadInt64(&c.success),
+		"vault_token_renewals_total{result=" + ResultError + "}":   atomic.LoadInt64(&c.errors),
+		"vault_token_renewals_total{result=" + ResultExpired + "}": atomic.LoadInt64(&c.expired),
+	}
+}
+
+// NewManager creates a new Manager. The returned Manager does not start
+// renewing the token until Start is called.
+func NewManager(config *ManagerConfig) *Manager {
+	if config == nil {
+		config = &ManagerConfig{}
+	}
+
+	return &Manager{
+		logger:            config.Logger,
+		client:            config.Client,
+		authMethod:        config.AuthMethod,
+		authHandlerConfig: config.AuthHandlerConfig,
+		sinks:             config.Sinks,
+		counters:          &Counters{},
+		healthyCh:         make(chan struct{}),
+		doneCh:            make(chan struct{}),
+	}
+}
+
+// Counters returns the Manager's renewal counters.
+func (m *Manager) Counters() *Counters {
+	return m.counters
+}
+
+// Healthy returns a channel that is closed the first time the managed
+// token is confirmed alive, either by an initial lookup-self or by a
+// successful renewal. Callers (e.g. Helper.Get) can block on this channel
+// instead of running a full auth handshake on every invocation.
+func (m *Manager) Healthy() <-chan struct{} {
+	return m.healthyCh
+}
+
+// Done returns a channel that is closed once the Manager has permanently
+// stopped managing the token, e.g. because re-authentication failed.
+func (m *Manager) Done() <-chan struct{} {
+	return m.doneCh
+}
+
+// Start begins renewing token in the background. It blocks only long
+// enough to look up the token's current TTL -- a real round trip to
+// Vault that confirms the token is alive right now, which is what lets
+// it close Healthy() before returning -- the renewal loop itself runs
+// in a goroutine and stops when ctx is canceled.
+func (m *Manager) Start(ctx context.Context, token string) error {
+	secret, err := m.client.Auth().Token().LookupSelf()
+	if err != nil {
+		return fmt.Errorf("error looking up token: %v", err)
+	}
+
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return fmt.Errorf("error reading token TTL: %v", err)
+	}
+
+	increment := m.renewIncrement(ttl)
+
+	watcher, err := m.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{
+		Secret: &api.Secret{
+			Auth: &api.SecretAuth{
+				ClientToken:   token,
+				LeaseDuration: int(ttl.Seconds()),
+				Renewable:     true,
+			},
+		},
+		Increment: int(increment.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating lifetime watcher: %v", err)
+	}
+
+	go watcher.Start()
+
+	// The LookupSelf above already confirmed the token is alive, so it
+	// is safe to report healthy now; run() only closes healthyCh again
+	// (a no-op) on subsequent successful renewals.
+	m.closeHealthy()
+
+	go m.run(ctx, watcher)
+
+	return nil
+}
+
+func (m *Manager) closeHealthy() {
+	select {
+	case <-m.healthyCh:
+	default:
+		close(m.healthyCh)
+	}
+}
+
+// renewIncrement computes the renewal grace window: the token's TTL minus
+// a random jitter of up to jitterFraction of the TTL, floored at
+// cache.GracePeriodSeconds.
+func (m *Manager) renewIncrement(ttl time.Duration) time.Duration {
+	jitter := time.Duration(rand.Float64() * jitterFraction * float64(ttl))
+	increment := ttl - jitter
+
+	floor := time.Duration(cache.GracePeriodSeconds) * time.Second
+	if increment < floor {
+		increment = floor
+	}
+	return increment
+}
+
+func (m *Manager) run(ctx context.Context, watcher *api.LifetimeWatcher) {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(m.doneCh)
+			return
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				m.logger.Error("Token renewer stopped renewing", "error", err)
+				m.counters.record(ResultError)
+
+				if isTokenGone(err) {
+					m.counters.record(ResultExpired)
+					if m.reauth(ctx) {
+						// Start launched a new run loop, which now
+						// owns closing doneCh.
+						return
+					}
+				}
+			}
+			close(m.doneCh)
+			return
+		case renewal := <-watcher.RenewCh():
+			m.logger.Info("Successfully renewed token", "remaining_duration", renewal.Secret.LeaseDuration)
+			m.counters.record(ResultSuccess)
+			m.closeHealthy()
+		}
+	}
+}
+
+// reauth is invoked when the managed token can no longer be renewed (it
+// is not renewable, was revoked, or the auth backend denied the renewal;
+// see isTokenGone). It drives the auth handler to obtain a new token,
+// sets that token on the shared client so Start's LookupSelf
+// authenticates as the new token rather than the one that just failed,
+// writes the token to the configured sinks so the stale one does not
+// keep getting read back out of the cache, and then restarts renewal.
+// It reports whether renewal was restarted.
+func (m *Manager) reauth(ctx context.Context) bool {
+	if m.authMethod == nil || m.authHandlerConfig == nil {
+		m.logger.Error("Token is no longer renewable and no auth method is configured for re-authentication")
+		return false
+	}
+
+	m.logger.Info("Re-authenticating to obtain a new token")
+
+	ah := auth.NewAuthHandler(m.authHandlerConfig)
+	go ah.Run(ctx, m.authMethod)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case token := <-ah.OutputCh:
+		m.client.SetToken(token)
+
+		if err := m.writeToSinks(ctx, token); err != nil {
+			m.logger.Error("Error writing re-authenticated token to sink(s)", "error", err)
+		}
+
+		if err := m.Start(ctx, token); err != nil {
+			m.logger.Error("Error restarting token lifecycle manager after re-authentication", "error", err)
+			return false
+		}
+		return true
+	}
+}
+
+// writeToSinks pushes token through a short-lived sink.SinkServer, the
+// same mechanism Helper.authenticate uses for the initial auth
+// handshake, so every configured sink (file/memory/keyring) ends up
+// holding the re-authenticated token instead of the stale one.
+func (m *Manager) writeToSinks(ctx context.Context, token string) error {
+	if len(m.sinks) == 0 {
+		return nil
+	}
+
+	ss := sink.NewSinkServer(&sink.SinkServerConfig{
+		Logger:        m.logger.Named("sink.server"),
+		Client:        m.client,
+		ExitAfterAuth: true,
+	})
+
+	newTokenCh := make(chan string, 1)
+	go ss.Run(ctx, newTokenCh, m.sinks)
+	newTokenCh <- token
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ss.DoneCh:
+		return nil
+	}
+}
+
+// isTokenGone reports whether err indicates the token itself is no
+// longer usable -- not renewable, revoked, or rejected as permission
+// denied -- as opposed to any other renewal error. Only the former
+// triggers re-authentication; on any other error run stops renewing and
+// closes Done without re-authenticating.
+func isTokenGone(err error) bool {
+	if err == api.ErrRenewerNotRenewable {
+		return true
+	}
+
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		// 403: permission denied (token revoked or policy no longer
+		// grants renew-self). 404: token lookup/renewal path returned
+		// "no handler for route", which Vault returns for a revoked or
+		// otherwise no-longer-existent token.
+		return respErr.StatusCode == 403 || respErr.StatusCode == 404
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+// Package registry implements the Docker Distribution v2 token
+// authentication flow: the challenge against /v2/ that a registry
+// returns via the WWW-Authenticate header, and the exchange of that
+// challenge for a short-lived bearer token at the challenge's realm.
+//
+// See https://docs.docker.com/registry/spec/auth/token/ for the
+// protocol this package implements.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTokenTTL is used when a token response omits expires_in, per
+// the distribution spec's guidance to assume a default lifetime.
+const defaultTokenTTL = 60 * time.Second
+
+// Challenge is the parsed Bearer challenge returned by a registry's
+// WWW-Authenticate header.
+type Challenge struct {
+	Realm   string
+	Service string
+	Scope   string
+}
+
+// Ping sends an unauthenticated request to serverURL's /v2/ endpoint and
+// parses the resulting Bearer challenge. It returns an error if the
+// registry does not challenge with Bearer auth (e.g. it uses Basic auth
+// or requires no auth at all).
+func Ping(client *http.Client, serverURL string) (*Challenge, error) {
+	pingURL := strings.TrimRight(serverURL, "/") + "/v2/"
+	if !strings.Contains(pingURL, "://") {
+		pingURL = "https://" + pingURL
+	}
+
+	resp, err := client.Get(pingURL)
+	if err != nil {
+		return nil, fmt.Errorf("error pinging %s: %v", pingURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, fmt.Errorf("expected HTTP 401 from %s, got %s", pingURL, resp.Status)
+	}
+
+	header := resp.Header.Get("WWW-Authenticate")
+	if header == "" {
+		return nil, fmt.Errorf("no WWW-Authenticate header returned by %s", pingURL)
+	}
+
+	return parseChallenge(header)
+}
+
+func parseChallenge(header string) (*Challenge, error) {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return nil, fmt.Errorf("unsupported WWW-Authenticate scheme %q; only Bearer is supported", header)
+	}
+
+	c := &Challenge{}
+	for _, pair := range splitChallengeParams(parts[1]) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		val := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		switch key {
+		case "realm":
+			c.Realm = val
+		case "service":
+			c.Service = val
+		case "scope":
+			c.Scope = val
+		}
+	}
+
+	if c.Realm == "" {
+		return nil, fmt.Errorf("WWW-Authenticate header %q is missing a realm", header)
+	}
+	return c, nil
+}
+
+// splitChallengeParams splits the comma-separated key="value" pairs of a
+// challenge, respecting commas that appear inside quoted values.
+func splitChallengeParams(s string) []string {
+	var (
+		parts     []string
+		inQuotes  bool
+		lastSplit int
+	)
+	for i, r := range s {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, s[lastSplit:i])
+				lastSplit = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[lastSplit:])
+	return parts
+}
+
+// Exchange performs the challenge's realm request using HTTP Basic auth
+// and returns the bearer token it grants, along with its TTL.
+func Exchange(client *http.Client, c *Challenge, username, password string) (string, time.Duration, error) {
+	u, err := url.Parse(c.Realm)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid realm %q: %v", c.Realm, err)
+	}
+
+	q := u.Query()
+	if c.Service != "" {
+		q.Set("service", c.Service)
+	}
+	if c.Scope != "" {
+		q.Set("scope", c.Scope)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.SetBasicAuth(username, password)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error exchanging token at %s: %v", c.Realm, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token exchange at %s returned %s", c.Realm, resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", 0, fmt.Errorf("error decoding token exchange response from %s: %v", c.Realm, err)
+	}
+
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return "", 0, fmt.Errorf("token exchange response from %s contained no token", c.Realm)
+	}
+
+	ttl := defaultTokenTTL
+	if body.ExpiresIn > 0 {
+		ttl = time.Duration(body.ExpiresIn) * time.Second
+	}
+	return token, ttl, nil
+}
+
+// cacheEntry is a bearer token cached until its expiration.
+type cacheEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// Cache stores bearer tokens keyed by (serverURL, scope) until they
+// expire, so that repeated credential lookups for the same registry and
+// scope do not repeat the ping/exchange round trip.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	client  *http.Client
+}
+
+// NewCache returns a Cache that performs ping/exchange requests with
+// client. If client is nil, http.DefaultClient is used.
+func NewCache(client *http.Client) *Cache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Cache{
+		entries: make(map[string]cacheEntry),
+		client:  client,
+	}
+}
+
+func cacheKey(serverURL, scope string) string {
+	return serverURL + "|" + scope
+}
+
+// Token returns a bearer token scoped to serverURL and scope, using a
+// Vault-issued username/password to perform the exchange when the cache
+// does not already hold an unexpired token.
+func (c *Cache) Token(serverURL, scope, username, password string) (string, error) {
+	key := cacheKey(serverURL, scope)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.token, nil
+	}
+
+	challenge, err := Ping(c.client, serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	// scope only overrides the scope the registry's own WWW-Authenticate
+	// challenge asked for when auto_auth.method.config.registry_scope is
+	// actually set; the common case is an unset (empty) scope, and
+	// blanking out the registry-requested scope (e.g.
+	// "repository:org/app:pull") would request an unscoped token that
+	// most registries reject or grant with no usable access.
+	if scope != "" {
+		challenge.Scope = scope
+	}
+
+	token, ttl, err := Exchange(c.client, challenge, username, password)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{
+		token:     token,
+		expiresAt: time.Now().Add(ttl),
+	}
+	c.mu.Unlock()
+
+	return token, nil
+}
@@ -0,0 +1,93 @@
+// Package keyring implements a sink.Sink that stores the token in the
+// OS's native credential store (Keychain on macOS, Secret Service on
+// Linux, Credential Manager on Windows) via github.com/99designs/keyring,
+// falling back to an encrypted file on disk when no native backend is
+// available.
+package keyring
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/99designs/keyring"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/morningconsult/docker-credential-vault-login/sink/sinkutil"
+)
+
+const (
+	defaultServiceName = "docker-credential-vault-login"
+	defaultItemKey      = "token"
+)
+
+// Sink stores the token as a single item in an OS keyring.
+type Sink struct {
+	client *api.Client
+	config *sink.SinkConfig
+	ring   keyring.Keyring
+	key    string
+}
+
+// NewSink constructs a keyring Sink. config.Config may set "service" and
+// "key" to override the default keyring service name and item key.
+func NewSink(config *sink.SinkConfig) (sink.Sink, error) {
+	service := defaultServiceName
+	if v, ok := config.Config["service"].(string); ok && v != "" {
+		service = v
+	}
+
+	key := defaultItemKey
+	if v, ok := config.Config["key"].(string); ok && v != "" {
+		key = v
+	}
+
+	fileDir := filepath.Join(os.TempDir(), "docker-credential-vault-login", "keyring")
+	if v, ok := config.Config["file_fallback_dir"].(string); ok && v != "" {
+		fileDir = v
+	}
+
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName: service,
+		AllowedBackends: []keyring.BackendType{
+			keyring.KeychainBackend,
+			keyring.SecretServiceBackend,
+			keyring.WinCredBackend,
+			keyring.FileBackend,
+		},
+		FileDir:          fileDir,
+		FilePasswordFunc: keyring.TerminalPrompt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening OS keyring: %v", err)
+	}
+
+	return &Sink{
+		client: config.Client,
+		config: config,
+		ring:   ring,
+		key:    key,
+	}, nil
+}
+
+// WriteToken implements sink.Sink.
+func (s *Sink) WriteToken(token string) error {
+	prepared, err := sinkutil.Prepare(s.client, s.config, token)
+	if err != nil {
+		return fmt.Errorf("error preparing token for keyring sink: %v", err)
+	}
+
+	return s.ring.Set(keyring.Item{
+		Key:  s.key,
+		Data: prepared,
+	})
+}
+
+// Token returns the token most recently written to the keyring.
+func (s *Sink) Token() ([]byte, error) {
+	item, err := s.ring.Get(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading token from keyring: %v", err)
+	}
+	return item.Data, nil
+}
@@ -0,0 +1,54 @@
+// Package memory implements a process-local sink.Sink backed by a
+// sync.Map, intended for long-lived daemon mode where the token only
+// needs to survive for the lifetime of the process rather than across
+// separate invocations of the helper binary.
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/morningconsult/docker-credential-vault-login/sink/sinkutil"
+)
+
+const tokenKey = "token"
+
+// Sink stores the most recently written token in memory.
+type Sink struct {
+	client *api.Client
+	config *sink.SinkConfig
+	tokens sync.Map
+}
+
+// NewSink constructs a memory Sink. Its signature matches the
+// func(*sink.SinkConfig) (sink.Sink, error) factory pattern used by the
+// built-in file sink.
+func NewSink(config *sink.SinkConfig) (sink.Sink, error) {
+	return &Sink{
+		client: config.Client,
+		config: config,
+	}, nil
+}
+
+// WriteToken implements sink.Sink.
+func (s *Sink) WriteToken(token string) error {
+	prepared, err := sinkutil.Prepare(s.client, s.config, token)
+	if err != nil {
+		return fmt.Errorf("error preparing token for memory sink: %v", err)
+	}
+
+	s.tokens.Store(tokenKey, prepared)
+	return nil
+}
+
+// Token returns the most recently written token, or false if WriteToken
+// has not yet been called.
+func (s *Sink) Token() ([]byte, bool) {
+	v, ok := s.tokens.Load(tokenKey)
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
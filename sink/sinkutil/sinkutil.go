@@ -0,0 +1,95 @@
+// Package sinkutil holds the response-wrapping and Diffie-Hellman
+// encryption logic shared by every sink.Sink implementation in this
+// repository, so that the memory and keyring sinks behave identically
+// to the built-in file sink with respect to WrapTTL, DHType, DHPath and
+// AAD.
+package sinkutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/helper/dhutil"
+)
+
+// Prepare renders token into the bytes a Sink should ultimately persist:
+// optionally response-wrapped, then optionally Diffie-Hellman encrypted,
+// exactly as the built-in file sink does.
+func Prepare(client *api.Client, config *sink.SinkConfig, token string) ([]byte, error) {
+	if config.WrapTTL != 0 {
+		wrapped, err := wrap(client, token, config.WrapTTL)
+		if err != nil {
+			return nil, fmt.Errorf("error wrapping token: %v", err)
+		}
+		token = wrapped
+	}
+
+	if config.DHType == "" {
+		return []byte(token), nil
+	}
+
+	theirPub, err := readDHPublicKey(config.DHPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DH public key from %s: %v", config.DHPath, err)
+	}
+
+	ourPub, ourPriv, err := dhutil.GeneratePublicPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("error generating DH keypair: %v", err)
+	}
+
+	aesKey, err := dhutil.GenerateSharedKey(ourPriv, theirPub)
+	if err != nil {
+		return nil, fmt.Errorf("error generating DH shared key: %v", err)
+	}
+
+	env, err := dhutil.EncryptAES(aesKey, []byte(token), []byte(config.AAD))
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting token: %v", err)
+	}
+	env.Curve25519PublicKey = ourPub
+
+	return json.Marshal(env)
+}
+
+func wrap(client *api.Client, token string, ttl time.Duration) (string, error) {
+	wrapClient, err := client.Clone()
+	if err != nil {
+		return "", err
+	}
+	wrapClient.SetToken(token)
+	wrapClient.SetWrappingLookupFunc(func(string, string) string {
+		return ttl.String()
+	})
+
+	secret, err := wrapClient.Logical().Write("auth/token/lookup-self", nil)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.WrapInfo == nil {
+		return "", fmt.Errorf("no wrap info returned for lookup-self")
+	}
+	return secret.WrapInfo.Token, nil
+}
+
+// readDHPublicKey reads the Curve25519 public key written by `vault
+// agent -output-curve-public-key` (or the docker-credential-vault-login
+// equivalent) at path.
+func readDHPublicKey(path string) ([]byte, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Curve25519PublicKey []byte `json:"curve25519_public_key"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data.Curve25519PublicKey, nil
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-        "fmt"
         "flag"
+        "fmt"
         "os"
 
         log "github.com/cihub/seelog"
+        "github.com/hashicorp/go-hclog"
         "github.com/docker/docker-credential-helpers/credentials"
-        helper "github.com/morningconsult/docker-credential-vault-login/vault-login"
+        "github.com/morningconsult/docker-credential-vault-login/daemon"
+        "github.com/morningconsult/docker-credential-vault-login/helper"
         "github.com/morningconsult/docker-credential-vault-login/vault-login/logging"
         "github.com/morningconsult/docker-credential-vault-login/vault-login/version"
 )
@@ -15,6 +17,11 @@ import (
 const banner = "Docker Credential Helper for Vault Storage v%s ('%s')\n"
 
 func main() {
+        if len(os.Args) > 1 && os.Args[1] == "daemon" {
+                runDaemon(os.Args[2:])
+                return
+        }
+
         var versionFlag bool
 	flag.BoolVar(&versionFlag, "version", false, "print version and exit")
 	flag.Parse()
@@ -27,6 +34,47 @@ func main() {
 
         defer log.Flush()
         logging.SetupLogger()
-        
+
+        socketPath := daemon.DefaultSocketPath
+        if p := os.Getenv(daemon.EnvSocketPath); p != "" {
+                socketPath = p
+        }
+
+        // If a daemon is already listening on the socket, become a thin
+        // client that proxies to it instead of reloading the config and
+        // running a full auth handshake in-process.
+        client := daemon.NewClient(socketPath)
+        if client.Available() {
+                credentials.Serve(client)
+                return
+        }
+
         credentials.Serve(helper.NewHelper(nil))
 }
+
+// runDaemon starts a long-running process that holds a single Vault
+// client and token lifecycle manager and serves get/store/erase/list
+// requests from other invocations of this binary over a Unix domain
+// socket, per the "daemon" subcommand.
+func runDaemon(args []string) {
+        fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+        socketPath := fs.String("socket", daemon.DefaultSocketPath, "path to the Unix domain socket to listen on")
+        fs.Parse(args)
+
+        if p := os.Getenv(daemon.EnvSocketPath); p != "" {
+                *socketPath = p
+        }
+
+        logging.SetupLogger()
+        defer log.Flush()
+
+        logger := hclog.Default()
+
+        h := helper.NewHelper(&helper.HelperOptions{Logger: logger})
+
+        srv := daemon.NewServer(logger, h)
+        if err := srv.ListenAndServe(*socketPath); err != nil {
+                fmt.Fprintln(os.Stderr, err)
+                os.Exit(1)
+        }
+}
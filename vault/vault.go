@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Credentials represents the username and password stored at a secret
+// path in Vault.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// GetCredentials reads the username and password stored at path.
+func GetCredentials(path string, client *api.Client) (*Credentials, error) {
+	mount, v2, err := isKVv2(path, client)
+	if err != nil {
+		return nil, fmt.Errorf("Error determining KV version of secret engine mounted at %q: %v", mount, err)
+	}
+
+	readPath := path
+	if v2 {
+		readPath = addPrefix(mount, path, "data")
+	}
+
+	secret, err := client.Logical().Read(readPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading secret %q: %v", path, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("No secret found at %q", path)
+	}
+
+	data := secret.Data
+	if v2 {
+		raw, ok := secret.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("No data found at %q (it may have been deleted)", path)
+		}
+		data = raw
+	}
+
+	username, _ := data["username"].(string)
+	password, _ := data["password"].(string)
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("Secret %q does not have both a 'username' and 'password' field", path)
+	}
+
+	return &Credentials{
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+// GetToken reads the "token" field stored at path, for use with the
+// identity_token credential type.
+func GetToken(path string, client *api.Client) (string, error) {
+	mount, v2, err := isKVv2(path, client)
+	if err != nil {
+		return "", fmt.Errorf("Error determining KV version of secret engine mounted at %q: %v", mount, err)
+	}
+
+	readPath := path
+	if v2 {
+		readPath = addPrefix(mount, path, "data")
+	}
+
+	secret, err := client.Logical().Read(readPath)
+	if err != nil {
+		return "", fmt.Errorf("Error reading secret %q: %v", path, err)
+	}
+	if secret == nil {
+		return "", fmt.Errorf("No secret found at %q", path)
+	}
+
+	data := secret.Data
+	if v2 {
+		raw, ok := secret.Data["data"].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("No data found at %q (it may have been deleted)", path)
+		}
+		data = raw
+	}
+
+	token, _ := data["token"].(string)
+	if token == "" {
+		return "", fmt.Errorf("Secret %q does not have a 'token' field", path)
+	}
+	return token, nil
+}
+
+// WriteCredentials writes creds to path, creating or overwriting the
+// secret as necessary.
+func WriteCredentials(path string, creds *Credentials, client *api.Client) error {
+	mount, v2, err := isKVv2(path, client)
+	if err != nil {
+		return fmt.Errorf("Error determining KV version of secret engine mounted at %q: %v", mount, err)
+	}
+
+	data := map[string]interface{}{
+		"username": creds.Username,
+		"password": creds.Password,
+	}
+
+	writePath := path
+	if v2 {
+		writePath = addPrefix(mount, path, "data")
+		data = map[string]interface{}{"data": data}
+	}
+
+	if _, err := client.Logical().Write(writePath, data); err != nil {
+		return fmt.Errorf("Error writing secret %q: %v", path, err)
+	}
+	return nil
+}
+
+// DeleteCredentials permanently deletes the secret at path. For KV v2
+// mounts this deletes the secret's metadata (and therefore all of its
+// versions); for KV v1 mounts it deletes the secret itself.
+func DeleteCredentials(path string, client *api.Client) error {
+	mount, v2, err := isKVv2(path, client)
+	if err != nil {
+		return fmt.Errorf("Error determining KV version of secret engine mounted at %q: %v", mount, err)
+	}
+
+	deletePath := path
+	if v2 {
+		deletePath = addPrefix(mount, path, "metadata")
+	}
+
+	if _, err := client.Logical().Delete(deletePath); err != nil {
+		return fmt.Errorf("Error deleting secret %q: %v", path, err)
+	}
+	return nil
+}
+
+// ListCredentials walks the secrets found under prefix and returns a map
+// of server URL (the last path segment of each secret) to username.
+func ListCredentials(prefix string, client *api.Client) (map[string]string, error) {
+	mount, v2, err := isKVv2(prefix, client)
+	if err != nil {
+		return nil, fmt.Errorf("Error determining KV version of secret engine mounted at %q: %v", mount, err)
+	}
+
+	listPath := prefix
+	if v2 {
+		listPath = addPrefix(mount, prefix, "metadata")
+	}
+
+	secret, err := client.Logical().List(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing secrets under %q: %v", prefix, err)
+	}
+	if secret == nil {
+		return map[string]string{}, nil
+	}
+
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return map[string]string{}, nil
+	}
+
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		name, ok := k.(string)
+		if !ok || strings.HasSuffix(name, "/") {
+			// Skip nested "directories"; ListCredentials only
+			// reads the leaf secrets directly under prefix.
+			continue
+		}
+
+		creds, err := GetCredentials(strings.TrimSuffix(prefix, "/")+"/"+name, client)
+		if err != nil {
+			continue
+		}
+		out[name] = creds.Username
+	}
+	return out, nil
+}
+
+// isKVv2 determines whether the secrets engine mounted at the prefix of
+// path is a version 1 or version 2 key/value store, as described in
+// https://www.vaultproject.io/api-docs/secret/kv/kv-v2.
+func isKVv2(path string, client *api.Client) (string, bool, error) {
+	resp, err := client.Logical().ReadWithData("sys/internal/ui/mounts/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", false, err
+	}
+	if resp == nil || resp.Data == nil {
+		return "", false, nil
+	}
+
+	mountPath, _ := resp.Data["path"].(string)
+
+	options, ok := resp.Data["options"].(map[string]interface{})
+	if !ok || options == nil {
+		return mountPath, false, nil
+	}
+
+	version, _ := options["version"].(string)
+	return mountPath, version == "2", nil
+}
+
+// addPrefix inserts apiPrefix ("data", "metadata", ...) after the mount
+// path of a KV v2 secret, e.g. addPrefix("secret/", "secret/foo", "data")
+// returns "secret/data/foo".
+func addPrefix(mount, path, apiPrefix string) string {
+	trimmed := strings.TrimPrefix(path, mount)
+	return mount + apiPrefix + "/" + trimmed
+}
@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// testClient returns an *api.Client for a Vault dev-mode server, skipping
+// the test unless VAULT_TEST_ADDR and VAULT_TEST_TOKEN are set. These
+// integration tests are not run as part of the normal unit test suite;
+// point them at `vault server -dev` to exercise the KV v1/v2 CRUD paths
+// against a real Vault.
+func testClient(t *testing.T) *api.Client {
+	t.Helper()
+
+	addr := os.Getenv("VAULT_TEST_ADDR")
+	token := os.Getenv("VAULT_TEST_TOKEN")
+	if addr == "" || token == "" {
+		t.Skip("VAULT_TEST_ADDR and VAULT_TEST_TOKEN must be set to run Vault integration tests")
+	}
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatalf("error creating Vault client: %v", err)
+	}
+	client.SetToken(token)
+	return client
+}
+
+func TestCredentialsCRUD_KVv2(t *testing.T) {
+	client := testClient(t)
+
+	mount := "secret-v2-test"
+	if err := client.Sys().Mount(mount, &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	}); err != nil {
+		t.Fatalf("error mounting kv v2 engine: %v", err)
+	}
+	defer client.Sys().Unmount(mount)
+
+	testCredentialsCRUD(t, client, mount)
+}
+
+func TestCredentialsCRUD_KVv1(t *testing.T) {
+	client := testClient(t)
+
+	mount := "secret-v1-test"
+	if err := client.Sys().Mount(mount, &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "1"},
+	}); err != nil {
+		t.Fatalf("error mounting kv v1 engine: %v", err)
+	}
+	defer client.Sys().Unmount(mount)
+
+	testCredentialsCRUD(t, client, mount)
+}
+
+func testCredentialsCRUD(t *testing.T, client *api.Client, mount string) {
+	t.Helper()
+
+	for i := 0; i < 3; i++ {
+		path := fmt.Sprintf("%s/docker-credential-vault-login/registry%d.example.com", mount, i)
+
+		creds := &Credentials{Username: "user", Password: fmt.Sprintf("pass%d", i)}
+		if err := WriteCredentials(path, creds, client); err != nil {
+			t.Fatalf("WriteCredentials(%q): %v", path, err)
+		}
+
+		got, err := GetCredentials(path, client)
+		if err != nil {
+			t.Fatalf("GetCredentials(%q): %v", path, err)
+		}
+		if got.Username != creds.Username || got.Password != creds.Password {
+			t.Fatalf("GetCredentials(%q) = %+v, want %+v", path, got, creds)
+		}
+	}
+
+	prefix := mount + "/docker-credential-vault-login"
+	list, err := ListCredentials(prefix, client)
+	if err != nil {
+		t.Fatalf("ListCredentials(%q): %v", prefix, err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("ListCredentials(%q) returned %d entries, want 3: %+v", prefix, len(list), list)
+	}
+
+	deletePath := prefix + "/registry0.example.com"
+	if err := DeleteCredentials(deletePath, client); err != nil {
+		t.Fatalf("DeleteCredentials(%q): %v", deletePath, err)
+	}
+	if _, err := GetCredentials(deletePath, client); err == nil {
+		t.Fatalf("GetCredentials(%q) succeeded after delete, want error", deletePath)
+	}
+}